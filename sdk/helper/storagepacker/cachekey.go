@@ -0,0 +1,48 @@
+package storagepacker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// CacheKeyFunc computes the in-memory cache / lock-stripe key for a
+// bucket key. The on-disk bucket key is untouched by this choice.
+type CacheKeyFunc func(key string) string
+
+// CacheKeyAlgorithm selects a CacheKeyFunc, for persistence in Config.
+type CacheKeyAlgorithm string
+
+const (
+	CacheKeyAlgorithmDefault CacheKeyAlgorithm = ""
+	CacheKeyAlgorithmXXHash  CacheKeyAlgorithm = "xxhash"
+)
+
+// CacheKeyFuncForAlgorithm resolves a persisted CacheKeyAlgorithm to
+// the CacheKeyFunc that implements it.
+func CacheKeyFuncForAlgorithm(alg CacheKeyAlgorithm) (CacheKeyFunc, error) {
+	switch alg {
+	case CacheKeyAlgorithmDefault:
+		return DefaultCacheKey, nil
+	case CacheKeyAlgorithmXXHash:
+		return XXHashCacheKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported cache key algorithm %q", alg)
+	}
+}
+
+// DefaultCacheKey is the original CacheKeyFunc: the full hex digest
+// with its path separators stripped. Bucket keys have / in them.
+// Entries in the radix tree do not. Lock hashing uses the latter form.
+func DefaultCacheKey(key string) string {
+	return strings.Replace(key, "/", "", -1)
+}
+
+// XXHashCacheKey is the opt-in fast-path CacheKeyFunc: a 64-bit
+// xxhash of the bucket key, rendered as 16 hex characters. Not
+// collision-resistant, so it must never be used for the on-disk
+// bucket key -- only for the in-memory radix tree and lock stripe.
+func XXHashCacheKey(key string) string {
+	return fmt.Sprintf("%016x", xxhash.Sum64String(key))
+}
@@ -0,0 +1,69 @@
+package storagepacker
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	radix "github.com/hashicorp/go-immutable-radix"
+)
+
+// digestsForBench returns n fake hex digests, stand-ins for the
+// output of GetItemIDHash, to drive the radix tree and lock-stripe
+// benchmarks below.
+func digestsForBench(n int) []string {
+	digests := make([]string, n)
+	for i := range digests {
+		digests[i] = fmt.Sprintf("%064x", i)
+	}
+	return digests
+}
+
+func benchmarkCacheKeyRadix(b *testing.B, keyFunc CacheKeyFunc) {
+	digests := digestsForBench(10000)
+
+	tree := radix.New()
+	for _, digest := range digests {
+		tree, _, _ = tree.Insert([]byte(keyFunc(digest)), struct{}{})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get([]byte(keyFunc(digests[i%len(digests)])))
+	}
+}
+
+func BenchmarkCacheKeyRadix_Default(b *testing.B) {
+	benchmarkCacheKeyRadix(b, DefaultCacheKey)
+}
+
+func BenchmarkCacheKeyRadix_XXHash(b *testing.B) {
+	benchmarkCacheKeyRadix(b, XXHashCacheKey)
+}
+
+// benchmarkCacheKeyLockStripe mimics striping a lock pool across
+// cache keys, the other hot path GetCacheKey feeds.
+func benchmarkCacheKeyLockStripe(b *testing.B, keyFunc CacheKeyFunc) {
+	const stripes = 256
+	var locks [stripes]sync.Mutex
+	digests := digestsForBench(10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keyFunc(digests[i%len(digests)])
+		idx := 0
+		for _, c := range key {
+			idx = (idx*31 + int(c)) % stripes
+		}
+		locks[idx].Lock()
+		locks[idx].Unlock()
+	}
+}
+
+func BenchmarkCacheKeyLockStripe_Default(b *testing.B) {
+	benchmarkCacheKeyLockStripe(b, DefaultCacheKey)
+}
+
+func BenchmarkCacheKeyLockStripe_XXHash(b *testing.B) {
+	benchmarkCacheKeyLockStripe(b, XXHashCacheKey)
+}
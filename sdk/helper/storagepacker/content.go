@@ -0,0 +1,157 @@
+package storagepacker
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// contentItemRequest pairs the content-keyed storage request for an
+// item's value with the ID-keyed index request that points at it.
+type contentItemRequest struct {
+	content *itemRequest
+	index   *itemRequest
+}
+
+// PutByContent stores items keyed by a hash of their value rather than
+// their ID, so identical payloads collapse to one bucket entry. Also
+// writes the ID->contentKey index entry GetByContent resolves through.
+func (s *StoragePackerV2) PutByContent(ctx context.Context, items []*Item) error {
+	requests := s.keysForItemsByContent(items)
+
+	// Duplicate content within a batch is expected, not an error; only
+	// build the dedup set if checkForDuplicateContent finds one.
+	var written map[string]bool
+	if dup, _ := checkForDuplicateContent(requests); dup {
+		written = make(map[string]bool, len(requests))
+	}
+
+	for _, r := range requests {
+		if written != nil {
+			if written[r.content.Key] {
+				continue
+			}
+			written[r.content.Key] = true
+		}
+		contentBucketKey, err := s.firstKey(r.content.Key)
+		if err != nil {
+			return err
+		}
+		contentBucket, err := s.GetBucket(ctx, contentBucketKey)
+		if err != nil {
+			return fmt.Errorf("failed to read content bucket %q: %w", contentBucketKey, err)
+		}
+		if bucketItemByContentKey(contentBucket, r.content.Key, s.contentKey) != nil {
+			continue
+		}
+		if err := s.putBucketItem(ctx, contentBucketKey, r.content.Value); err != nil {
+			return fmt.Errorf("failed to store content for item %q: %w", r.content.ID, err)
+		}
+	}
+
+	for _, r := range requests {
+		indexBucketKey, err := s.firstKey(r.index.Key)
+		if err != nil {
+			return err
+		}
+		if err := s.putBucketItem(ctx, indexBucketKey, r.index.Value); err != nil {
+			return fmt.Errorf("failed to store content index for item %q: %w", r.index.ID, err)
+		}
+	}
+	return nil
+}
+
+// GetByContent resolves id through the index PutByContent maintains,
+// returning the shared value (nil if id was never put by content).
+func (s *StoragePackerV2) GetByContent(ctx context.Context, id string) (*Item, error) {
+	indexKey := s.keyForID(id)
+	indexBucketKey, err := s.firstKey(indexKey)
+	if err != nil {
+		return nil, err
+	}
+	indexBucket, err := s.GetBucket(ctx, indexBucketKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content index bucket %q: %w", indexBucketKey, err)
+	}
+
+	indexItem := bucketItemByID(indexBucket, id)
+	if indexItem == nil {
+		return nil, nil
+	}
+	contentKey := string(indexItem.Value)
+
+	contentBucketKey, err := s.firstKey(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	contentBucket, err := s.GetBucket(ctx, contentBucketKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content bucket %q: %w", contentBucketKey, err)
+	}
+	return bucketItemByContentKey(contentBucket, contentKey, s.contentKey), nil
+}
+
+// bucketItemByID returns bucket's item with the given ID, or nil.
+func bucketItemByID(bucket *Bucket, id string) *Item {
+	if bucket == nil {
+		return nil
+	}
+	for _, item := range bucket.Items {
+		if item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// bucketItemByContentKey returns bucket's item whose value hashes to
+// contentKey under keyOf, or nil.
+func bucketItemByContentKey(bucket *Bucket, contentKey string, keyOf func(*Item) string) *Item {
+	if bucket == nil {
+		return nil
+	}
+	for _, item := range bucket.Items {
+		if keyOf(item) == contentKey {
+			return item
+		}
+	}
+	return nil
+}
+
+// keysForItemsByContent computes the content-keyed and ID-keyed index
+// requests for each item.
+func (s *StoragePackerV2) keysForItemsByContent(items []*Item) []*contentItemRequest {
+	requests := make([]*contentItemRequest, 0, len(items))
+	for _, i := range items {
+		contentKey := s.contentKey(i)
+
+		requests = append(requests, &contentItemRequest{
+			content: &itemRequest{ID: i.ID, Key: contentKey, Value: i},
+			index: &itemRequest{
+				ID:    i.ID,
+				Key:   s.keyForID(i.ID),
+				Value: &Item{ID: i.ID, Value: []byte(contentKey)},
+			},
+		})
+	}
+	return requests
+}
+
+// contentKey hashes item's value alone (not its ID), so identical
+// payloads under different IDs collapse to the same key.
+func (s *StoragePackerV2) contentKey(item *Item) string {
+	return hex.EncodeToString(s.HashFunc(string(item.Value)))
+}
+
+// checkForDuplicateContent reports whether any two requests share a
+// content key, like checkForDuplicateIds but keyed on payload hash.
+func checkForDuplicateContent(requests []*contentItemRequest) (bool, string) {
+	keysSeen := make(map[string]bool, len(requests))
+	for _, r := range requests {
+		if _, found := keysSeen[r.content.Key]; found {
+			return true, r.content.Key
+		}
+		keysSeen[r.content.Key] = true
+	}
+	return false, ""
+}
@@ -0,0 +1,86 @@
+package storagepacker
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeBucketStorage is an in-memory BucketStorage for exercising
+// StoragePackerV2 without a real physical backend.
+type fakeBucketStorage struct {
+	buckets map[string]*Bucket
+}
+
+func newFakeBucketStorage() *fakeBucketStorage {
+	return &fakeBucketStorage{buckets: make(map[string]*Bucket)}
+}
+
+func (f *fakeBucketStorage) GetBucket(ctx context.Context, key string) (*Bucket, error) {
+	return f.buckets[key], nil
+}
+
+func (f *fakeBucketStorage) PutBucket(ctx context.Context, key string, bucket *Bucket) error {
+	f.buckets[key] = bucket
+	return nil
+}
+
+func newTestStoragePacker(t *testing.T) *StoragePackerV2 {
+	t.Helper()
+	s, err := NewStoragePackerV2(newFakeBucketStorage(), Config{
+		BaseBucketBits: 8,
+		HashAlgorithm:  HashAlgorithmSHA256,
+	})
+	if err != nil {
+		t.Fatalf("NewStoragePackerV2: %v", err)
+	}
+	return s
+}
+
+func TestPutByContentRoundTrip(t *testing.T) {
+	s := newTestStoragePacker(t)
+	ctx := context.Background()
+
+	item := &Item{ID: "entity-1", Value: []byte("hello world")}
+	if err := s.PutByContent(ctx, []*Item{item}); err != nil {
+		t.Fatalf("PutByContent: %v", err)
+	}
+
+	got, err := s.GetByContent(ctx, "entity-1")
+	if err != nil {
+		t.Fatalf("GetByContent: %v", err)
+	}
+	if got == nil {
+		t.Fatal("GetByContent returned nil, want the stored item")
+	}
+	if string(got.Value) != "hello world" {
+		t.Fatalf("got value %q, want %q", got.Value, "hello world")
+	}
+}
+
+func TestPutByContentDedupesIdenticalPayloads(t *testing.T) {
+	s := newTestStoragePacker(t)
+	ctx := context.Background()
+
+	items := []*Item{
+		{ID: "entity-1", Value: []byte("shared payload")},
+		{ID: "entity-2", Value: []byte("shared payload")},
+	}
+	if err := s.PutByContent(ctx, items); err != nil {
+		t.Fatalf("PutByContent: %v", err)
+	}
+
+	got1, err := s.GetByContent(ctx, "entity-1")
+	if err != nil {
+		t.Fatalf("GetByContent(entity-1): %v", err)
+	}
+	got2, err := s.GetByContent(ctx, "entity-2")
+	if err != nil {
+		t.Fatalf("GetByContent(entity-2): %v", err)
+	}
+	if got1 == nil || got2 == nil {
+		t.Fatal("expected both IDs to resolve to the shared content")
+	}
+	if string(got1.Value) != "shared payload" || string(got2.Value) != "shared payload" {
+		t.Fatalf("got values %q and %q, want both %q", got1.Value, got2.Value, "shared payload")
+	}
+}
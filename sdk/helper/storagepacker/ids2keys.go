@@ -1,13 +1,15 @@
 package storagepacker
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/hashicorp/vault/sdk/helper/cryptoutil"
 	"math"
 	"sort"
-	"strings"
 )
 
 type itemRequest struct {
@@ -21,8 +23,81 @@ type itemRequest struct {
 	Value *Item
 }
 
-func GetItemIDHash(itemID string) string {
-	return hex.EncodeToString(cryptoutil.Blake2b256Hash(itemID))
+// HashFunc computes the digest used to derive an item's storage key.
+type HashFunc func(string) []byte
+
+// HashAlgorithm identifies a HashFunc, for persistence in Config.
+type HashAlgorithm string
+
+const (
+	HashAlgorithmBlake2b256 HashAlgorithm = "blake2b-256"
+	HashAlgorithmSHA256     HashAlgorithm = "sha2-256"
+	HashAlgorithmSHA1       HashAlgorithm = "sha1"
+	HashAlgorithmMD5        HashAlgorithm = "md5"
+)
+
+// HashFuncForAlgorithm resolves a persisted HashAlgorithm to the
+// HashFunc that implements it. The empty string means Blake2b-256.
+func HashFuncForAlgorithm(alg HashAlgorithm) (HashFunc, error) {
+	switch alg {
+	case "", HashAlgorithmBlake2b256:
+		return cryptoutil.Blake2b256Hash, nil
+	case HashAlgorithmSHA256:
+		return func(s string) []byte {
+			sum := sha256.Sum256([]byte(s))
+			return sum[:]
+		}, nil
+	case HashAlgorithmSHA1:
+		return func(s string) []byte {
+			sum := sha1.Sum([]byte(s))
+			return sum[:]
+		}, nil
+	case HashAlgorithmMD5:
+		return func(s string) []byte {
+			sum := md5.Sum([]byte(s))
+			return sum[:]
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", alg)
+	}
+}
+
+// HashAlgorithmForHexLength dispatches on a pre-hashed hex ID's
+// length, bazel-remote style (32/40/64 -> MD5/SHA-1/SHA-256).
+func HashAlgorithmForHexLength(length int) (HashAlgorithm, bool) {
+	switch length {
+	case 32:
+		return HashAlgorithmMD5, true
+	case 40:
+		return HashAlgorithmSHA1, true
+	case 64:
+		return HashAlgorithmSHA256, true
+	default:
+		return "", false
+	}
+}
+
+// digestBits returns the number of bits produced by the packer's
+// configured HashFunc.
+func (s *StoragePackerV2) digestBits() int {
+	return len(s.HashFunc("")) * 8
+}
+
+// GetItemIDHash returns the hex-encoded digest of itemID under the
+// packer's configured HashFunc.
+func (s *StoragePackerV2) GetItemIDHash(itemID string) string {
+	return hex.EncodeToString(s.HashFunc(itemID))
+}
+
+// keyForID returns the storage key for an ID: itemID itself if it's
+// already a recognized pre-hashed hex digest, otherwise its hash.
+func (s *StoragePackerV2) keyForID(itemID string) string {
+	if _, ok := HashAlgorithmForHexLength(len(itemID)); ok {
+		if _, err := hex.DecodeString(itemID); err == nil {
+			return itemID
+		}
+	}
+	return s.GetItemIDHash(itemID)
 }
 
 // Given a list of IDs, calculate their keys generate itemRequests for each.
@@ -31,7 +106,7 @@ func (s *StoragePackerV2) keysForIDs(ids []string) []*itemRequest {
 	for _, id := range ids {
 		requests = append(requests, &itemRequest{
 			ID:    id,
-			Key:   GetItemIDHash(id),
+			Key:   s.keyForID(id),
 			Value: nil,
 		})
 	}
@@ -44,7 +119,7 @@ func (s *StoragePackerV2) keysForItems(items []*Item) []*itemRequest {
 	for _, i := range items {
 		requests = append(requests, &itemRequest{
 			ID:    i.ID,
-			Key:   GetItemIDHash(i.ID),
+			Key:   s.keyForID(i.ID),
 			Value: i,
 		})
 	}
@@ -72,20 +147,35 @@ func checkForDuplicateIds(ids []string) (bool, string) {
 	return false, ""
 }
 
+// rootShardLength returns the root bucket key's length in hex chars,
+// sized off s.HashFunc's actual digest rather than a fixed 256 bits.
+func (s *StoragePackerV2) rootShardLength() (int, error) {
+	if digestBits := s.digestBits(); s.BaseBucketBits > digestBits {
+		return 0, fmt.Errorf("root shard of %d bits exceeds the configured %d-bit digest", s.BaseBucketBits, digestBits)
+	}
+	return s.BaseBucketBits / 4, nil
+}
+
 // Return the topmost bucket in the tree for a given key.
 // Used as a defult if the cache is empty or bypassed.
-func (s *StoragePackerV2) firstKey(cacheKey string) (string, error) {
-	rootShardLength := s.BaseBucketBits / 4
-	if len(cacheKey) < rootShardLength {
-		return cacheKey, errors.New("Key too short.")
+func (s *StoragePackerV2) firstKey(key string) (string, error) {
+	rootShardLength, err := s.rootShardLength()
+	if err != nil {
+		return "", err
+	}
+	if len(key) < rootShardLength {
+		return key, errors.New("Key too short.")
 	}
-	return cacheKey[0 : s.BaseBucketBits/4], nil
+	return key[0:rootShardLength], nil
 }
 
 // Return all topmost buckets in the tree.
-func (s *StoragePackerV2) getAllBaseBucketKeys() []string {
+func (s *StoragePackerV2) getAllBaseBucketKeys() ([]string, error) {
+	rootBucketLength, err := s.rootShardLength()
+	if err != nil {
+		return nil, err
+	}
 	numBuckets := int(math.Pow(2.0, float64(s.BaseBucketBits)))
-	rootBucketLength := s.BaseBucketBits / 4
 
 	// %02x for default configuration, could be %01x, %03x, etc.
 	formatString := fmt.Sprintf("%%0%dx", rootBucketLength)
@@ -95,12 +185,16 @@ func (s *StoragePackerV2) getAllBaseBucketKeys() []string {
 		bucketKey := fmt.Sprintf(formatString, i)
 		ret = append(ret, bucketKey)
 	}
-	return ret
+	return ret, nil
 }
 
-// Buckets keys have / in them.
-// Entries in the radix tree do not.
-// Lock hashing uses the latter form.
+// GetCacheKey returns the in-memory cache / lock-stripe key for key,
+// via the packer's configured CacheKeyFunc. key must be the on-disk
+// digest -- route to a base bucket via firstKey(key), not
+// firstKey(s.GetCacheKey(key)), since the two can diverge.
 func (s *StoragePackerV2) GetCacheKey(key string) string {
-	return strings.Replace(key, "/", "", -1)
-}
\ No newline at end of file
+	if s.CacheKeyFunc == nil {
+		return DefaultCacheKey(key)
+	}
+	return s.CacheKeyFunc(key)
+}
@@ -0,0 +1,158 @@
+package storagepacker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/bits"
+)
+
+// MerkleRoot computes an RFC 6962-style Merkle root over the packer's
+// base buckets, so two packers can cheaply attest to holding
+// identical item sets without streaming every entry.
+func (s *StoragePackerV2) MerkleRoot(ctx context.Context) ([]byte, error) {
+	leaves, err := s.merkleLeaves(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.merkleRootForRange(leaves), nil
+}
+
+// MerkleProof returns the sibling hashes on the path between the root
+// and bucketKey's leaf, in top-down order (the root's child first, the
+// leaf's sibling last), so a single bucket can be verified against a
+// published root.
+func (s *StoragePackerV2) MerkleProof(ctx context.Context, bucketKey string) ([][]byte, error) {
+	keys, err := s.getAllBaseBucketKeys()
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, key := range keys {
+		if key == bucketKey {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return nil, fmt.Errorf("unknown base bucket key %q", bucketKey)
+	}
+
+	leaves, err := s.merkleLeaves(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var proof [][]byte
+	s.collectMerkleProof(leaves, index, &proof)
+	return proof, nil
+}
+
+// merkleLeaves reads every base bucket and hashes it into an RFC 6962
+// leaf, in the same lexicographic order used to build the root. A
+// never-allocated bucket and one that holds zero items both hash as
+// an empty item list, so they produce the same leaf.
+func (s *StoragePackerV2) merkleLeaves(ctx context.Context) ([][]byte, error) {
+	keys, err := s.getAllBaseBucketKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		bucket, err := s.GetBucket(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bucket %q: %w", key, err)
+		}
+
+		var items []*Item
+		if bucket != nil {
+			items = bucket.Items
+		}
+
+		canon, err := s.canonicalBucketBytes(items)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize bucket %q: %w", key, err)
+		}
+		leaves = append(leaves, s.leafHash(canon))
+	}
+	return leaves, nil
+}
+
+// canonicalBucketBytes serializes a bucket's items in sorted key
+// order, so the leaf hash for a bucket doesn't depend on the order
+// items were put into it.
+func (s *StoragePackerV2) canonicalBucketBytes(items []*Item) ([]byte, error) {
+	sorted := sortRequests(s.keysForItems(items))
+
+	var buf bytes.Buffer
+	for _, r := range sorted {
+		valueBytes, err := r.Value.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal item %q: %w", r.ID, err)
+		}
+		buf.WriteString(r.Key)
+		buf.Write(valueBytes)
+	}
+	return buf.Bytes(), nil
+}
+
+// emptyTreeHash is the RFC 6962 hash of an empty subtree, H("").
+func (s *StoragePackerV2) emptyTreeHash() []byte {
+	return s.HashFunc("")
+}
+
+// leafHash is the RFC 6962 leaf hash, H(0x00 || data).
+func (s *StoragePackerV2) leafHash(data []byte) []byte {
+	return s.HashFunc(string(append([]byte{0x00}, data...)))
+}
+
+// innerHash is the RFC 6962 inner node hash, H(0x01 || left || right).
+func (s *StoragePackerV2) innerHash(left, right []byte) []byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, 0x01)
+	buf = append(buf, left...)
+	buf = append(buf, right...)
+	return s.HashFunc(string(buf))
+}
+
+// merkleRootForRange computes the RFC 6962 root over an already
+// leaf-hashed slice, splitting at the largest power of two strictly
+// less than len(leaves) as the spec requires.
+func (s *StoragePackerV2) merkleRootForRange(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return s.emptyTreeHash()
+	case 1:
+		return leaves[0]
+	default:
+		k := merkleSplitPoint(len(leaves))
+		left := s.merkleRootForRange(leaves[:k])
+		right := s.merkleRootForRange(leaves[k:])
+		return s.innerHash(left, right)
+	}
+}
+
+// collectMerkleProof walks the same split points merkleRootForRange
+// uses, recording the sibling subtree root at each level on the path
+// to leaves[index].
+func (s *StoragePackerV2) collectMerkleProof(leaves [][]byte, index int, proof *[][]byte) {
+	if len(leaves) <= 1 {
+		return
+	}
+
+	k := merkleSplitPoint(len(leaves))
+	if index < k {
+		*proof = append(*proof, s.merkleRootForRange(leaves[k:]))
+		s.collectMerkleProof(leaves[:k], index, proof)
+	} else {
+		*proof = append(*proof, s.merkleRootForRange(leaves[:k]))
+		s.collectMerkleProof(leaves[k:], index-k, proof)
+	}
+}
+
+// merkleSplitPoint returns the largest power of two strictly less
+// than n, per RFC 6962's left/right subtree split.
+func merkleSplitPoint(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
@@ -0,0 +1,115 @@
+package storagepacker
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func putItems(t *testing.T, s *StoragePackerV2, items []*Item) {
+	t.Helper()
+	ctx := context.Background()
+	for _, item := range items {
+		key, err := s.firstKey(s.GetItemIDHash(item.ID))
+		if err != nil {
+			t.Fatalf("firstKey: %v", err)
+		}
+		if err := s.putBucketItem(ctx, key, item); err != nil {
+			t.Fatalf("putBucketItem: %v", err)
+		}
+	}
+}
+
+func TestMerkleRootIndependentOfInsertionOrder(t *testing.T) {
+	items := []*Item{
+		{ID: "entity-1", Value: []byte("one")},
+		{ID: "entity-2", Value: []byte("two")},
+		{ID: "entity-3", Value: []byte("three")},
+	}
+
+	forward := newTestStoragePacker(t)
+	putItems(t, forward, items)
+
+	reversed := newTestStoragePacker(t)
+	putItems(t, reversed, []*Item{items[2], items[1], items[0]})
+
+	ctx := context.Background()
+	rootForward, err := forward.MerkleRoot(ctx)
+	if err != nil {
+		t.Fatalf("MerkleRoot(forward): %v", err)
+	}
+	rootReversed, err := reversed.MerkleRoot(ctx)
+	if err != nil {
+		t.Fatalf("MerkleRoot(reversed): %v", err)
+	}
+
+	if !bytes.Equal(rootForward, rootReversed) {
+		t.Fatalf("roots differ by insertion order: %x != %x", rootForward, rootReversed)
+	}
+}
+
+func TestMerkleProofRecomputesRoot(t *testing.T) {
+	s := newTestStoragePacker(t)
+	items := []*Item{
+		{ID: "entity-1", Value: []byte("one")},
+		{ID: "entity-2", Value: []byte("two")},
+		{ID: "entity-3", Value: []byte("three")},
+	}
+	putItems(t, s, items)
+
+	ctx := context.Background()
+	root, err := s.MerkleRoot(ctx)
+	if err != nil {
+		t.Fatalf("MerkleRoot: %v", err)
+	}
+
+	bucketKey, err := s.firstKey(s.GetItemIDHash(items[0].ID))
+	if err != nil {
+		t.Fatalf("firstKey: %v", err)
+	}
+	bucket, err := s.GetBucket(ctx, bucketKey)
+	if err != nil {
+		t.Fatalf("GetBucket: %v", err)
+	}
+	canon, err := s.canonicalBucketBytes(bucket.Items)
+	if err != nil {
+		t.Fatalf("canonicalBucketBytes: %v", err)
+	}
+
+	proof, err := s.MerkleProof(ctx, bucketKey)
+	if err != nil {
+		t.Fatalf("MerkleProof: %v", err)
+	}
+
+	keys, err := s.getAllBaseBucketKeys()
+	if err != nil {
+		t.Fatalf("getAllBaseBucketKeys: %v", err)
+	}
+	index := -1
+	for i, key := range keys {
+		if key == bucketKey {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		t.Fatalf("bucket key %q not found among base buckets", bucketKey)
+	}
+
+	// MerkleProof returns siblings root-to-leaf (see collectMerkleProof),
+	// so fold it leaf-to-root by walking it back to front.
+	recomputed := s.leafHash(canon)
+	for i := len(proof) - 1; i >= 0; i-- {
+		sibling := proof[i]
+		if index%2 == 0 {
+			recomputed = s.innerHash(recomputed, sibling)
+		} else {
+			recomputed = s.innerHash(sibling, recomputed)
+		}
+		index /= 2
+	}
+
+	if !bytes.Equal(recomputed, root) {
+		t.Fatalf("proof did not fold up to the published root: %x != %x", recomputed, root)
+	}
+}
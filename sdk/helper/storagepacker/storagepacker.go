@@ -0,0 +1,126 @@
+package storagepacker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Item is a single value tracked by the packer, keyed by ID.
+type Item struct {
+	ID    string
+	Value []byte
+}
+
+// Marshal returns Item's canonical byte encoding, used for Merkle
+// hashing.
+func (i *Item) Marshal() ([]byte, error) {
+	return append([]byte(i.ID+"\x00"), i.Value...), nil
+}
+
+// Bucket is the unit of on-disk storage: every item whose ID hashes
+// into this bucket's shard lives in Items.
+type Bucket struct {
+	Key   string
+	Items []*Item
+}
+
+// BucketStorage is the minimal storage interface StoragePackerV2 needs
+// to read and write its base buckets; concrete packers back it with
+// the physical backend's storage.
+type BucketStorage interface {
+	GetBucket(ctx context.Context, key string) (*Bucket, error)
+	PutBucket(ctx context.Context, key string, bucket *Bucket) error
+}
+
+// Config is the packer's persisted, on-disk configuration. It is
+// written once at construction time and read back on every restart so
+// buckets keyed under it remain interpretable.
+type Config struct {
+	// BaseBucketBits sets the number of bits of the configured digest
+	// used to select a base bucket/shard.
+	BaseBucketBits int
+
+	// HashAlgorithm is the digest used for item ID keying (and, by
+	// extension, Merkle hashing and content addressing). Persisted so
+	// HashFuncForAlgorithm re-resolves the same HashFunc on restart.
+	HashAlgorithm HashAlgorithm
+
+	// CacheKeyAlgorithm selects the in-memory cache/lock-stripe key
+	// function (see CacheKeyFuncForAlgorithm). Unlike HashAlgorithm
+	// this only affects process-local state, so it's safe to change
+	// between restarts.
+	CacheKeyAlgorithm CacheKeyAlgorithm
+}
+
+// StoragePackerV2 packs many small items into a fixed set of base
+// buckets so they can be read and written without one storage entry
+// per item.
+type StoragePackerV2 struct {
+	Config
+
+	Storage BucketStorage
+
+	// HashFunc is resolved from Config.HashAlgorithm by
+	// NewStoragePackerV2; see HashFuncForAlgorithm.
+	HashFunc HashFunc
+
+	// CacheKeyFunc is resolved from Config.CacheKeyAlgorithm by
+	// NewStoragePackerV2; see CacheKeyFuncForAlgorithm.
+	CacheKeyFunc CacheKeyFunc
+}
+
+// NewStoragePackerV2 builds a StoragePackerV2 from config, resolving
+// its persisted HashAlgorithm and CacheKeyAlgorithm to concrete funcs.
+// Callers restoring a packer across a restart should pass back the
+// same Config that was used to create it, so existing buckets stay
+// readable.
+func NewStoragePackerV2(storage BucketStorage, config Config) (*StoragePackerV2, error) {
+	hashFunc, err := HashFuncForAlgorithm(config.HashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure storage packer: %w", err)
+	}
+
+	cacheKeyFunc, err := CacheKeyFuncForAlgorithm(config.CacheKeyAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure storage packer: %w", err)
+	}
+
+	return &StoragePackerV2{
+		Config:       config,
+		Storage:      storage,
+		HashFunc:     hashFunc,
+		CacheKeyFunc: cacheKeyFunc,
+	}, nil
+}
+
+// GetBucket reads the base bucket stored under key, or nil if no
+// bucket has ever been written there.
+func (s *StoragePackerV2) GetBucket(ctx context.Context, key string) (*Bucket, error) {
+	return s.Storage.GetBucket(ctx, key)
+}
+
+// PutBucket writes bucket under key.
+func (s *StoragePackerV2) PutBucket(ctx context.Context, key string, bucket *Bucket) error {
+	return s.Storage.PutBucket(ctx, key, bucket)
+}
+
+// putBucketItem upserts item into the bucket addressed by key,
+// replacing any existing item with the same ID.
+func (s *StoragePackerV2) putBucketItem(ctx context.Context, key string, item *Item) error {
+	bucket, err := s.GetBucket(ctx, key)
+	if err != nil {
+		return err
+	}
+	if bucket == nil {
+		bucket = &Bucket{Key: key}
+	}
+
+	for i, existing := range bucket.Items {
+		if existing.ID == item.ID {
+			bucket.Items[i] = item
+			return s.PutBucket(ctx, key, bucket)
+		}
+	}
+	bucket.Items = append(bucket.Items, item)
+	return s.PutBucket(ctx, key, bucket)
+}